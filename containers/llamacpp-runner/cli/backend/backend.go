@@ -0,0 +1,102 @@
+// Package backend defines the pluggable interface model-runtime backends
+// implement so app-bricks can pull models through llama.cpp, an external
+// gRPC plugin, or any future runtime without forking the CLI.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Progress reports the state of an in-flight pull.
+type Progress struct {
+	Percent    int
+	BytesDone  int64
+	BytesTotal int64
+	Stage      string
+	// Err is the human-readable reason the pull failed. Set only when
+	// Stage is "error".
+	Err string
+}
+
+// Puller is implemented by every model-runtime backend capable of pulling a
+// model reference.
+type Puller interface {
+	// Name identifies the backend, e.g. "llama-run" or "plugin:vllm".
+	Name() string
+	// Capabilities lists the ref schemes this backend can handle.
+	Capabilities() []string
+	// Pull starts downloading ref, streaming progress until the channel is
+	// closed. The returned error is only for pulls that fail to start.
+	Pull(ctx context.Context, ref string) (<-chan Progress, error)
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Puller{}
+)
+
+// Register adds a backend to the registry, keyed by its Name.
+func Register(p Puller) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[p.Name()] = p
+}
+
+// Lookup returns the backend registered under name.
+func Lookup(name string) (Puller, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+	return p, nil
+}
+
+// ForRef picks the backend whose Capabilities() includes ref's scheme,
+// falling back to the backend named fallback when ref carries no
+// recognizable scheme. Only the "huggingface://" and "hf:" prefixes are
+// recognized as schemes; any other ref, including one of another
+// "<scheme>://" form, goes to fallback. This lets Ollama
+// refs and refs from other sources (e.g. Hugging Face) flow through the
+// same pull path uniformly.
+func ForRef(ref, fallback string) (Puller, error) {
+	if scheme := refScheme(ref); scheme != "" {
+		mu.RLock()
+		defer mu.RUnlock()
+		for _, p := range registry {
+			for _, c := range p.Capabilities() {
+				if c == scheme {
+					return p, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("no backend registered for scheme %q", scheme)
+	}
+	return Lookup(fallback)
+}
+
+func refScheme(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "huggingface://"):
+		return "huggingface"
+	case strings.HasPrefix(ref, "hf:"):
+		return "hf"
+	default:
+		return ""
+	}
+}
+
+// Names returns the names of all registered backends.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/arduino/app-bricks-py/model-downloader/huggingface"
+)
+
+// HuggingFace pulls GGUF files from the Hugging Face Hub, recognizing
+// "huggingface://" and "hf:" refs.
+type HuggingFace struct{}
+
+func (HuggingFace) Name() string { return "huggingface" }
+
+func (HuggingFace) Capabilities() []string { return []string{"huggingface", "hf"} }
+
+func (HuggingFace) Pull(ctx context.Context, ref string) (<-chan Progress, error) {
+	progress := make(chan Progress, 16)
+
+	go func() {
+		defer close(progress)
+		bytes, err := huggingface.Pull(ctx, ref, huggingface.DefaultOptions(), func(done, total int64) {
+			percent := 0
+			if total > 0 {
+				percent = int(done * 100 / total)
+			}
+			progress <- Progress{Percent: percent, BytesDone: done, BytesTotal: total, Stage: "download"}
+		})
+		stage := "done"
+		var errMsg string
+		if err != nil {
+			stage = "error"
+			errMsg = err.Error()
+		}
+		progress <- Progress{Percent: 100, BytesDone: bytes, Stage: stage, Err: errMsg}
+	}()
+
+	return progress, nil
+}
+
+func init() {
+	Register(HuggingFace{})
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/app-bricks-py/model-downloader/llamacppwrapper"
+)
+
+// LlamaRun is the backend app-bricks has always used. It now pulls model
+// blobs directly from the Ollama registry (see llamacppwrapper) instead of
+// shelling out to llama-run; the name is kept for CLI compatibility.
+type LlamaRun struct{}
+
+func (LlamaRun) Name() string { return "llama-run" }
+
+func (LlamaRun) Capabilities() []string { return []string{"ollama"} }
+
+func (LlamaRun) Pull(ctx context.Context, ref string) (<-chan Progress, error) {
+	progress := make(chan Progress, 16)
+
+	go func() {
+		defer close(progress)
+		result := llamacppwrapper.DownloadMode(ctx, ref, func(done, total int64) {
+			percent := 0
+			if total > 0 {
+				percent = int(done * 100 / total)
+			}
+			progress <- Progress{Percent: percent, BytesDone: done, BytesTotal: total, Stage: "download"}
+		})
+		stage := "done"
+		var errMsg string
+		switch {
+		case result.Err != nil:
+			stage = "error"
+			errMsg = result.Err.Error()
+		case result.ExitCode != 0:
+			stage = "error"
+			errMsg = fmt.Sprintf("pull of %s failed with exit code %d", ref, result.ExitCode)
+		}
+		progress <- Progress{Percent: 100, BytesDone: result.Bytes, Stage: stage, Err: errMsg}
+	}()
+
+	return progress, nil
+}
+
+func init() {
+	Register(LlamaRun{})
+}
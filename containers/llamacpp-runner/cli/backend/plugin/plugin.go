@@ -0,0 +1,126 @@
+// Package plugin implements the external backend: app-bricks discovers
+// executables in a plugin directory, spawns them, and drives them over a
+// small line-delimited JSON protocol on the child's stdout. This lets new
+// runtimes (llama.cpp server, mlc, vLLM) be added without forking this repo
+// and without app-bricks itself depending on a protobuf toolchain.
+//
+// A plugin binary is invoked as `<binary> pull --ref <ref>` and, until the
+// pull finishes, writes one JSON object per line to stdout:
+//
+//	{"stage":"download","percent":42,"bytes_done":1234,"bytes_total":5678}
+//	{"stage":"done","percent":100}
+//	{"stage":"error","error":"connection reset"}
+//
+// See testdata/dummybackend for a minimal reference implementation, used by
+// plugin_test.go.
+//
+// The protocol has no Status or Cancel message. Each call to Pull spawns its
+// own plugin process, so cancelling the ctx passed to Pull kills that one
+// pull's process without affecting other pulls through the same plugin. See
+// docs/plugin-protocol.md.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/arduino/app-bricks-py/model-downloader/backend"
+)
+
+// message is one line of the plugin protocol.
+type message struct {
+	Stage      string `json:"stage"`
+	Percent    int    `json:"percent"`
+	BytesDone  int64  `json:"bytes_done"`
+	BytesTotal int64  `json:"bytes_total"`
+	Error      string `json:"error"`
+}
+
+// Backend drives an external executable implementing the plugin protocol.
+type Backend struct {
+	binary string
+}
+
+// Discover looks for executables in dir and registers one Backend per
+// executable found, keyed as "plugin:<basename>".
+func Discover(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0o111 == 0 {
+			continue
+		}
+		backend.Register(&Backend{binary: filepath.Join(dir, entry.Name())})
+	}
+	return nil
+}
+
+func (b *Backend) Name() string { return "plugin:" + filepath.Base(b.binary) }
+
+func (b *Backend) Capabilities() []string { return []string{"plugin"} }
+
+func (b *Backend) Pull(ctx context.Context, ref string) (<-chan backend.Progress, error) {
+	cmd := exec.CommandContext(ctx, b.binary, "pull", "--ref", ref)
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("opening stdout for plugin %s: %w", b.binary, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting plugin %s: %w", b.binary, err)
+	}
+
+	progress := make(chan backend.Progress, 16)
+	go func() {
+		defer close(progress)
+
+		var terminal bool
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			var msg message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				progress <- backend.Progress{Stage: "error", Err: fmt.Sprintf("plugin %s sent malformed progress: %v", b.binary, err)}
+				terminal = true
+				break
+			}
+			if msg.Stage == "done" || msg.Stage == "error" {
+				terminal = true
+			}
+			progress <- backend.Progress{
+				Percent:    msg.Percent,
+				BytesDone:  msg.BytesDone,
+				BytesTotal: msg.BytesTotal,
+				Stage:      msg.Stage,
+				Err:        msg.Error,
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			progress <- backend.Progress{Stage: "error", Err: fmt.Sprintf("reading plugin %s output: %v", b.binary, err)}
+			terminal = true
+		}
+
+		// If the plugin exited without ever sending a terminal stage
+		// (it crashed, was killed, or exited non-zero mid-stream), its
+		// process error is the only evidence the pull failed.
+		if err := cmd.Wait(); err != nil && !terminal {
+			progress <- backend.Progress{Stage: "error", Err: fmt.Sprintf("plugin %s: %v", b.binary, err)}
+		}
+	}()
+
+	return progress, nil
+}
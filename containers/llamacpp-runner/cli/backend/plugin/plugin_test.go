@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/arduino/app-bricks-py/model-downloader/backend"
+)
+
+// buildTestBackend compiles the package under testdata/<name> into a temp
+// binary so Backend.Pull has a real plugin executable to spawn.
+func buildTestBackend(t *testing.T, name string) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), name)
+	cmd := exec.Command("go", "build", "-o", bin, "./testdata/"+name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building %s: %v\n%s", name, err, out)
+	}
+	return bin
+}
+
+func buildDummyBackend(t *testing.T) string {
+	return buildTestBackend(t, "dummybackend")
+}
+
+func TestBackendPullStreamsDummyBackendProgress(t *testing.T) {
+	b := &Backend{binary: buildDummyBackend(t)}
+
+	progress, err := b.Pull(context.Background(), "gemma3:1b")
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	var events []backend.Progress
+	for p := range progress {
+		events = append(events, p)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+	if events[0].Stage != "download" || events[0].Percent != 50 {
+		t.Errorf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Stage != "done" || events[1].BytesTotal != 1024 {
+		t.Errorf("unexpected last event: %+v", events[1])
+	}
+}
+
+// TestBackendPullSynthesizesErrorOnCrash guards against a plugin that
+// exits non-zero without ever writing a terminal "done"/"error" line being
+// silently reported as a successful pull.
+func TestBackendPullSynthesizesErrorOnCrash(t *testing.T) {
+	b := &Backend{binary: buildTestBackend(t, "crashingbackend")}
+
+	progress, err := b.Pull(context.Background(), "gemma3:1b")
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+
+	var events []backend.Progress
+	for p := range progress {
+		events = append(events, p)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2 (one progress line, one synthesized error): %+v", len(events), events)
+	}
+	if events[0].Stage != "download" {
+		t.Errorf("unexpected first event: %+v", events[0])
+	}
+	if events[1].Stage != "error" || events[1].Err == "" {
+		t.Errorf("expected a synthesized error event for the crashed process, got: %+v", events[1])
+	}
+}
+
+func TestDiscoverRegistersOnlyExecutables(t *testing.T) {
+	dir := t.TempDir()
+
+	data, err := os.ReadFile(buildDummyBackend(t))
+	if err != nil {
+		t.Fatalf("reading dummy backend binary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dummybackend"), data, 0o755); err != nil {
+		t.Fatalf("writing dummy backend binary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a backend"), 0o644); err != nil {
+		t.Fatalf("writing non-executable file: %v", err)
+	}
+
+	if err := Discover(dir); err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	if _, err := backend.Lookup("plugin:dummybackend"); err != nil {
+		t.Errorf("expected plugin:dummybackend to be registered: %v", err)
+	}
+	if _, err := backend.Lookup("plugin:readme.txt"); err == nil {
+		t.Errorf("non-executable file should not have been registered as a backend")
+	}
+}
@@ -0,0 +1,14 @@
+// Command crashingbackend emits one progress line, then exits non-zero
+// without ever sending a terminal "done"/"error" stage, exercising the
+// Backend.Pull path that has to notice the process itself failed.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println(`{"stage":"download","percent":10,"bytes_done":100,"bytes_total":1000}`)
+	os.Exit(1)
+}
@@ -0,0 +1,11 @@
+// Command dummybackend is a minimal reference implementation of the
+// app-bricks plugin protocol (see ../../plugin.go), used by plugin_test.go
+// to exercise Backend.Pull end-to-end without a real model runtime.
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println(`{"stage":"download","percent":50,"bytes_done":512,"bytes_total":1024}`)
+	fmt.Println(`{"stage":"done","percent":100,"bytes_done":1024,"bytes_total":1024}`)
+}
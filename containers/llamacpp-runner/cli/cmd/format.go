@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Supported values for the --format flag. "human" draws the ASCII progress
+// bars; "json"/"ndjson" emit one JSON event per line instead, so tools
+// (IDEs, CI) can parse progress reliably instead of scraping the
+// carriage-return-overwritten bar line.
+const (
+	FormatHuman  = "human"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+func init() {
+	rootCmd.PersistentFlags().String("format", FormatHuman, "Output format: human, json, or ndjson")
+}
+
+// progressEvent, doneEvent, and errorEvent are the per-line schemas
+// documented in docs/progress-events.md. Each event type gets its own
+// struct so a field that's merely absent on one event type (e.g.
+// exit_code on "progress") never appears on the wire at all, rather than
+// leaking as a spurious zero value.
+type progressEvent struct {
+	Event      string `json:"event"`
+	Model      string `json:"model"`
+	Stage      string `json:"stage"`
+	Percent    int    `json:"percent"`
+	BytesDone  int64  `json:"bytes_done,omitempty"`
+	BytesTotal int64  `json:"bytes_total,omitempty"`
+	Timestamp  int64  `json:"ts"`
+}
+
+type doneEvent struct {
+	Event     string `json:"event"`
+	ExitCode  int    `json:"exit_code"`
+	Timestamp int64  `json:"ts"`
+}
+
+type errorEvent struct {
+	Event     string `json:"event"`
+	Model     string `json:"model"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"ts"`
+}
+
+func emitEvent(e any) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
+func emitProgress(model, stage string, percent int, bytesDone, bytesTotal int64) {
+	emitEvent(progressEvent{Event: "progress", Model: model, Stage: stage, Percent: percent, BytesDone: bytesDone, BytesTotal: bytesTotal, Timestamp: time.Now().Unix()})
+}
+
+func emitDone(exitCode int) {
+	emitEvent(doneEvent{Event: "done", ExitCode: exitCode, Timestamp: time.Now().Unix()})
+}
+
+func emitError(model, message string) {
+	emitEvent(errorEvent{Event: "error", Model: model, Message: message, Timestamp: time.Now().Unix()})
+}
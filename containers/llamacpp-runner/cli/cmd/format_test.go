@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStdout redirects os.Stdout for the duration of fn and returns
+// everything written to it.
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	fn()
+	w.Close()
+	os.Stdout = orig
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading captured stdout: %v", err)
+	}
+	return out
+}
+
+func decodeLine(t *testing.T, line []byte) map[string]any {
+	t.Helper()
+	var decoded map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(line), &decoded); err != nil {
+		t.Fatalf("unmarshalling event line %q: %v", line, err)
+	}
+	return decoded
+}
+
+// TestEmitProgressMatchesSchema checks a "progress" event against the
+// fields docs/progress-events.md documents as always present, including at
+// 0%, which a naive omitempty tag would otherwise drop.
+func TestEmitProgressMatchesSchema(t *testing.T) {
+	line := decodeLine(t, captureStdout(t, func() {
+		emitProgress("gemma3:1b", "download", 0, 0, 1024)
+	}))
+
+	for _, field := range []string{"event", "model", "stage", "percent", "ts"} {
+		if _, ok := line[field]; !ok {
+			t.Errorf("progress event missing required field %q: %v", field, line)
+		}
+	}
+	if line["event"] != "progress" {
+		t.Errorf("event = %v, want %q", line["event"], "progress")
+	}
+	if _, ok := line["exit_code"]; ok {
+		t.Errorf("progress event must not carry exit_code (a done-only field): %v", line)
+	}
+}
+
+// TestEmitDoneAlwaysIncludesExitCode guards against the omitempty bug that
+// dropped exit_code on success, which is also the zero value.
+func TestEmitDoneAlwaysIncludesExitCode(t *testing.T) {
+	line := decodeLine(t, captureStdout(t, func() {
+		emitDone(0)
+	}))
+
+	if _, ok := line["exit_code"]; !ok {
+		t.Errorf("done event missing exit_code for a successful (0) run: %v", line)
+	}
+	if line["event"] != "done" {
+		t.Errorf("event = %v, want %q", line["event"], "done")
+	}
+	if _, ok := line["percent"]; ok {
+		t.Errorf("done event must not carry percent (a progress-only field): %v", line)
+	}
+}
+
+// TestEmitErrorIncludesMessage checks the error event carries the
+// human-readable message documented in docs/progress-events.md.
+func TestEmitErrorIncludesMessage(t *testing.T) {
+	line := decodeLine(t, captureStdout(t, func() {
+		emitError("gemma3:1b", "boom")
+	}))
+
+	if line["event"] != "error" {
+		t.Errorf("event = %v, want %q", line["event"], "error")
+	}
+	if line["model"] != "gemma3:1b" {
+		t.Errorf("model = %v, want gemma3:1b", line["model"])
+	}
+	if line["message"] != "boom" {
+		t.Errorf("message = %v, want %q", line["message"], "boom")
+	}
+	for _, field := range []string{"percent", "exit_code"} {
+		if _, ok := line[field]; ok {
+			t.Errorf("error event must not carry %q: %v", field, line)
+		}
+	}
+}
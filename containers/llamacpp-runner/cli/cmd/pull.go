@@ -1,46 +1,236 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
 
-	"github.com/arduino/app-bricks-py/model-downloader/llamacppwrapper"
+	"github.com/arduino/app-bricks-py/model-downloader/backend/plugin"
+	"github.com/arduino/app-bricks-py/model-downloader/pkg/modeldl"
+	"github.com/cheggaaa/pb/v3"
 	"github.com/spf13/cobra"
 )
 
 // pullCmd represents the pull command
 var pullCmd = &cobra.Command{
-	Use:   "pull",
-	Short: "Pull a model from a remote repository",
-	Long:  `Pull a model from a remote repository. Supported sources: Ollama library.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		downloadModel(cmd)
+	Use:   "pull <model> [model...]",
+	Short: "Pull one or more models from a remote repository",
+	Long: `Pull one or more models from a remote repository.
+
+Supported sources: the Ollama library (e.g. gemma3:1b), and the Hugging Face
+Hub via "hf:org/repo:quant" or "huggingface://org/repo/file.gguf".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return downloadModels(cmd, args)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(pullCmd)
-	pullCmd.Flags().StringP("model", "m", "", "Model to pull. E.g.: gemma3:1b")
+	pullCmd.Flags().StringP("model", "m", "", "Model to pull. E.g.: gemma3:1b (deprecated, pass models as positional arguments instead)")
+	pullCmd.Flags().IntP("jobs", "j", 2, "Maximum number of models to pull concurrently")
+	pullCmd.Flags().String("backend", "llama-run", "Backend to pull through. Built-in: llama-run. External plugins found in the plugin dir are named plugin:<binary>")
+	pullCmd.Flags().String("registry", "registry.ollama.ai", "Registry host to resolve models against")
+	pullCmd.Flags().Bool("insecure", false, "Talk to the registry over plain HTTP")
+	pullCmd.Flags().String("auth", "", "Authorization header to send with registry requests")
+}
+
+// pluginDir returns the directory app-bricks scans for external backend
+// plugins, honoring APP_BRICKS_PLUGIN_DIR if set.
+func pluginDir() string {
+	if dir := os.Getenv("APP_BRICKS_PLUGIN_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".app-bricks", "plugins")
 }
 
-func downloadModel(cmd *cobra.Command) error {
-	if model, err := cmd.Flags().GetString("model"); err != nil {
+// downloadModels is a thin cobra adapter: it resolves flags into a
+// modeldl.Client call per model and renders the results, either as ASCII
+// progress bars or as --format json/ndjson events. All the actual pulling
+// happens in pkg/modeldl.
+func downloadModels(cmd *cobra.Command, args []string) error {
+	models := append([]string{}, args...)
+	if legacy, err := cmd.Flags().GetString("model"); err != nil {
 		return err
-	} else {
-		if model == "" {
-			return fmt.Errorf("model flag is required")
+	} else if legacy != "" {
+		models = append(models, legacy)
+	}
+	if len(models) == 0 {
+		return fmt.Errorf("at least one model is required")
+	}
+
+	jobs, err := cmd.Flags().GetInt("jobs")
+	if err != nil {
+		return err
+	}
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(models) {
+		jobs = len(models)
+	}
+
+	if dir := pluginDir(); dir != "" {
+		if err := plugin.Discover(dir); err != nil {
+			return fmt.Errorf("discovering plugins in %s: %w", dir, err)
 		}
+	}
 
-		fmt.Printf("Pulling model: %s\n", model)
-		exitCode, err := llamacppwrapper.DownloadMode(model)
+	opts, err := pullOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+
+	// A SIGINT now cancels every in-flight pull's context cleanly instead of
+	// leaving an orphaned exec.Command behind.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := modeldl.New()
+
+	var bars []*pb.ProgressBar
+	var pool *pb.Pool
+	if format == FormatHuman {
+		bars = make([]*pb.ProgressBar, len(models))
+		for i, model := range models {
+			bars[i] = pb.New(100).Set("prefix", model+" ")
+		}
+		pool, err = pb.StartPool(bars...)
 		if err != nil {
 			return err
 		}
-		if exitCode != 0 {
-			return fmt.Errorf("failed to pull model, exit code: %d", exitCode)
-		} else {
-			fmt.Println("Model pulled successfully")
+	}
+
+	type result struct {
+		model string
+		err   error
+	}
+	results := make([]result, len(models))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i, model := range models {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, model string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var bar *pb.ProgressBar
+			if bars != nil {
+				bar = bars[i]
+			}
+			results[i] = result{model: model, err: pullOne(ctx, client, model, format, opts, bar)}
+		}(i, model)
+	}
+
+	wg.Wait()
+	if pool != nil {
+		pool.Stop()
+	}
+
+	var failed []result
+	for _, res := range results {
+		if res.err != nil {
+			failed = append(failed, res)
+		}
+	}
+
+	exitCode := 0
+	if len(failed) > 0 {
+		exitCode = 1
+	}
+
+	if format != FormatHuman {
+		emitDone(exitCode)
+	} else if len(failed) > 0 {
+		for _, res := range failed {
+			fmt.Printf("failed to pull %s: %v\n", res.model, res.err)
 		}
+	} else {
+		fmt.Println("All models pulled successfully")
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d models failed to pull", len(failed), len(models))
+	}
 	return nil
 }
+
+// pullOptions builds modeldl.PullOptions from the --backend, --registry,
+// --insecure, and --auth flags.
+func pullOptions(cmd *cobra.Command) (modeldl.PullOptions, error) {
+	var opts modeldl.PullOptions
+
+	backendName, err := cmd.Flags().GetString("backend")
+	if err != nil {
+		return opts, err
+	}
+	opts.Backend = backendName
+
+	registry, err := cmd.Flags().GetString("registry")
+	if err != nil {
+		return opts, err
+	}
+	opts.Registry = registry
+
+	insecure, err := cmd.Flags().GetBool("insecure")
+	if err != nil {
+		return opts, err
+	}
+	opts.Insecure = insecure
+
+	auth, err := cmd.Flags().GetString("auth")
+	if err != nil {
+		return opts, err
+	}
+	opts.Auth = auth
+
+	return opts, nil
+}
+
+// pullOne drives a single pull through client. In FormatHuman it relays
+// progress onto bar; otherwise it emits one JSON/NDJSON event per update.
+func pullOne(ctx context.Context, client *modeldl.Client, model, format string, opts modeldl.PullOptions, bar *pb.ProgressBar) error {
+	handle, err := client.Pull(ctx, model, opts)
+	if err != nil {
+		if format != FormatHuman {
+			emitError(model, err.Error())
+		}
+		return err
+	}
+
+	for e := range handle.Progress() {
+		switch format {
+		case FormatHuman:
+			if e.BytesTotal > 0 {
+				bar.SetTotal(e.BytesTotal)
+				bar.SetCurrent(e.BytesDone)
+			} else {
+				bar.SetCurrent(int64(e.Percent))
+			}
+		default:
+			emitProgress(model, e.Stage, e.Percent, e.BytesDone, e.BytesTotal)
+		}
+	}
+
+	err = handle.Wait()
+	if bar != nil {
+		bar.Finish()
+	}
+	if err != nil && format != FormatHuman {
+		emitError(model, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/arduino/app-bricks-py/model-downloader/backend"
+	"github.com/spf13/cobra"
+)
+
+// fakeBackend is a backend.Puller whose Pull blocks on release until the
+// test lets it finish, so downloadModels's worker pool can be observed
+// mid-flight. A ref of "bad-model" fails to start at all, exercising the
+// path where one model's failure must not affect the others.
+type fakeBackend struct {
+	inflight    int32
+	maxInflight int32
+	release     chan struct{}
+}
+
+func (f *fakeBackend) Name() string           { return "faketest" }
+func (f *fakeBackend) Capabilities() []string { return nil }
+
+func (f *fakeBackend) Pull(ctx context.Context, ref string) (<-chan backend.Progress, error) {
+	if ref == "bad-model" {
+		return nil, fmt.Errorf("boom")
+	}
+
+	n := atomic.AddInt32(&f.inflight, 1)
+	for {
+		max := atomic.LoadInt32(&f.maxInflight)
+		if n <= max || atomic.CompareAndSwapInt32(&f.maxInflight, max, n) {
+			break
+		}
+	}
+
+	progress := make(chan backend.Progress, 2)
+	go func() {
+		defer close(progress)
+		defer atomic.AddInt32(&f.inflight, -1)
+		progress <- backend.Progress{Stage: "download", Percent: 50}
+		<-f.release
+		progress <- backend.Progress{Stage: "done", Percent: 100}
+	}()
+	return progress, nil
+}
+
+// testPullCmd returns pullCmd configured for a test run: format=json (so no
+// terminal progress bars are involved) and --backend pointed at fb, with
+// flags restored once the test finishes.
+func testPullCmd(t *testing.T, fb *fakeBackend) *cobra.Command {
+	t.Helper()
+	backend.Register(fb)
+
+	if pullCmd.Flags().Lookup("format") == nil {
+		pullCmd.Flags().AddFlagSet(rootCmd.PersistentFlags())
+	}
+	t.Cleanup(func() {
+		pullCmd.Flags().Set("format", FormatHuman)
+		pullCmd.Flags().Set("backend", "llama-run")
+		pullCmd.Flags().Set("jobs", "2")
+	})
+
+	if err := pullCmd.Flags().Set("format", FormatJSON); err != nil {
+		t.Fatalf("setting --format: %v", err)
+	}
+	if err := pullCmd.Flags().Set("backend", fb.Name()); err != nil {
+		t.Fatalf("setting --backend: %v", err)
+	}
+	if err := pullCmd.Flags().Set("jobs", "2"); err != nil {
+		t.Fatalf("setting --jobs: %v", err)
+	}
+	return pullCmd
+}
+
+// TestDownloadModelsCapsConcurrencyAndIsolatesFailures drives four models
+// through downloadModels with --jobs 2, one of which fails to even start.
+// It asserts the worker pool never runs more than --jobs pulls at once, and
+// that the failing model doesn't block or drop the others' results.
+func TestDownloadModelsCapsConcurrencyAndIsolatesFailures(t *testing.T) {
+	fb := &fakeBackend{release: make(chan struct{})}
+	cmd := testPullCmd(t, fb)
+	models := []string{"m1", "m2", "m3", "bad-model"}
+
+	var downloadErr error
+	out := captureStdout(t, func() {
+		errCh := make(chan error, 1)
+		go func() { errCh <- downloadModels(cmd, models) }()
+
+		time.Sleep(100 * time.Millisecond)
+		close(fb.release)
+		downloadErr = <-errCh
+	})
+
+	if got := atomic.LoadInt32(&fb.maxInflight); got > 2 {
+		t.Errorf("max concurrent pulls = %d, want <= 2 (the --jobs cap)", got)
+	}
+
+	if downloadErr == nil {
+		t.Fatal("downloadModels returned nil error, want one reporting bad-model's failure")
+	}
+	if !strings.Contains(downloadErr.Error(), "1 of 4") {
+		t.Errorf("downloadModels error = %q, want it to report 1 of 4 models failing", downloadErr.Error())
+	}
+
+	gotProgress := map[string]bool{}
+	gotError := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		event := decodeLine(t, []byte(line))
+		switch event["event"] {
+		case "progress":
+			gotProgress[fmt.Sprint(event["model"])] = true
+		case "error":
+			gotError[fmt.Sprint(event["model"])] = true
+		}
+	}
+
+	for _, model := range []string{"m1", "m2", "m3"} {
+		if !gotProgress[model] {
+			t.Errorf("missing progress event for %s: the failing model must not lose other models' results", model)
+		}
+	}
+	if !gotError["bad-model"] {
+		t.Error("missing error event for bad-model")
+	}
+}
@@ -0,0 +1,34 @@
+// Command embed demonstrates pulling a model from another Go program by
+// embedding pkg/modeldl directly, without going through the app-bricks CLI.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/arduino/app-bricks-py/model-downloader/pkg/modeldl"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: embed <model-ref>")
+		os.Exit(1)
+	}
+
+	client := modeldl.New()
+	handle, err := client.Pull(context.Background(), os.Args[1], modeldl.PullOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for event := range handle.Progress() {
+		fmt.Printf("\r%s: %d%%", event.Model, event.Percent)
+	}
+	fmt.Println()
+
+	if err := handle.Wait(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,329 @@
+// Package huggingface pulls GGUF models from the Hugging Face Hub,
+// extending app-bricks beyond the Ollama-only library. Refs look like
+// "huggingface://TheBloke/Llama-2-7B-GGUF/llama-2-7b.Q4_K_M.gguf" or the
+// shorthand "hf:TheBloke/Llama-2-7B-GGUF:Q4_K_M".
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures where models are downloaded from and to.
+type Options struct {
+	// Endpoint is the Hub API root, e.g. "https://huggingface.co".
+	Endpoint string
+	// Auth, if set, is sent as the Authorization header (e.g. "Bearer hf_...").
+	Auth string
+	// ModelDir is where downloaded files and their manifests live.
+	ModelDir string
+}
+
+// DefaultOptions returns the options used when the CLI is invoked without
+// overrides.
+func DefaultOptions() Options {
+	return Options{
+		Endpoint: "https://huggingface.co",
+		ModelDir: "models",
+	}
+}
+
+// sibling mirrors the subset of the Hub's /api/models/<repo> response
+// describing one file in the repo.
+type sibling struct {
+	Filename string `json:"rfilename"`
+}
+
+type modelInfo struct {
+	Siblings []sibling `json:"siblings"`
+}
+
+// responseHeaderTimeout bounds how long we wait for the Hub to start
+// responding to a request. It must not bound the body read too: GGUF files
+// are routinely multi-GB, and a single deadline covering the whole request
+// (as http.Client.Timeout does) would abort any real download partway
+// through. Overall cancellation is the caller's ctx.
+const responseHeaderTimeout = 30 * time.Second
+
+// newHTTPClient returns a client whose Timeout is unbounded so that slow
+// file transfers aren't killed mid-stream; only the time to first response
+// byte is bounded, via the transport's ResponseHeaderTimeout.
+func newHTTPClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: responseHeaderTimeout}}
+}
+
+// manifest is written alongside a downloaded file so a later Pull of the
+// same ref is a no-op.
+type manifest struct {
+	Repo      string `json:"repo"`
+	File      string `json:"file"`
+	ETag      string `json:"etag"`
+	Size      int64  `json:"size"`
+	FetchedAt string `json:"fetched_at"`
+}
+
+// ParseRef recognizes "huggingface://<repo>/<file>" and the shorthand
+// "hf:<org>/<repo>:<quant>", returning ok=false for anything else.
+func ParseRef(ref string) (repo, file, quant string, ok bool) {
+	switch {
+	case strings.HasPrefix(ref, "huggingface://"):
+		rest := strings.TrimPrefix(ref, "huggingface://")
+		parts := strings.SplitN(rest, "/", 3)
+		if len(parts) != 3 {
+			return "", "", "", false
+		}
+		return parts[0] + "/" + parts[1], parts[2], "", true
+
+	case strings.HasPrefix(ref, "hf:"):
+		rest := strings.TrimPrefix(ref, "hf:")
+		repoPart := rest
+		if at := strings.LastIndex(rest, ":"); at != -1 {
+			repoPart = rest[:at]
+			quant = rest[at+1:]
+		}
+		if strings.Count(repoPart, "/") != 1 {
+			return "", "", "", false
+		}
+		return repoPart, "", quant, true
+
+	default:
+		return "", "", "", false
+	}
+}
+
+// Pull resolves ref against the Hub, downloading the matching GGUF file with
+// resumable range requests, and reports aggregate byte progress via
+// onProgress(bytesDone, bytesTotal). It returns the number of bytes
+// downloaded this call (0 if a prior manifest already covers the file).
+func Pull(ctx context.Context, ref string, opts Options, onProgress func(done, total int64)) (int64, error) {
+	repo, file, quant, ok := ParseRef(ref)
+	if !ok {
+		return 0, fmt.Errorf("not a huggingface ref: %s", ref)
+	}
+
+	client := newHTTPClient()
+
+	if file == "" {
+		resolved, err := resolveFile(ctx, client, opts, repo, quant)
+		if err != nil {
+			return 0, err
+		}
+		file = resolved
+	}
+
+	destDir := filepath.Join(opts.ModelDir, filepath.FromSlash(repo))
+	destPath := filepath.Join(destDir, file)
+	manifestPath := destPath + ".manifest.json"
+
+	etag, size, err := headFile(ctx, client, opts, repo, file)
+	if err != nil {
+		return 0, err
+	}
+
+	if existing, err := readManifest(manifestPath); err == nil && existing.ETag == etag {
+		if info, statErr := os.Stat(destPath); statErr == nil && info.Size() == size {
+			if onProgress != nil {
+				onProgress(size, size)
+			}
+			return 0, nil
+		}
+	}
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	n, err := downloadFile(ctx, client, opts, repo, file, destPath, size, onProgress)
+	if err != nil {
+		return n, err
+	}
+
+	m := manifest{Repo: repo, File: file, ETag: etag, Size: size, FetchedAt: time.Now().UTC().Format(time.RFC3339)}
+	if err := writeManifest(manifestPath, m); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// resolveFile queries the Hub for repo's file list and returns the GGUF
+// sibling whose name contains quant (case-insensitively), or the only GGUF
+// file if quant is empty and there is exactly one.
+func resolveFile(ctx context.Context, client *http.Client, opts Options, repo, quant string) (string, error) {
+	url := fmt.Sprintf("%s/api/models/%s", opts.Endpoint, repo)
+	req, err := newRequest(ctx, opts, http.MethodGet, url)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("querying %s returned %s", repo, resp.Status)
+	}
+
+	var info modelInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+
+	var ggufFiles []string
+	for _, s := range info.Siblings {
+		if strings.HasSuffix(strings.ToLower(s.Filename), ".gguf") {
+			ggufFiles = append(ggufFiles, s.Filename)
+		}
+	}
+
+	if quant != "" {
+		for _, f := range ggufFiles {
+			if strings.Contains(strings.ToUpper(f), strings.ToUpper(quant)) {
+				return f, nil
+			}
+		}
+		return "", fmt.Errorf("no GGUF file matching quant %q in %s", quant, repo)
+	}
+
+	if len(ggufFiles) == 1 {
+		return ggufFiles[0], nil
+	}
+	return "", fmt.Errorf("%s has %d GGUF files; specify a quant tag to disambiguate", repo, len(ggufFiles))
+}
+
+func resolveURL(opts Options, repo, file string) string {
+	return fmt.Sprintf("%s/%s/resolve/main/%s", opts.Endpoint, repo, file)
+}
+
+func newRequest(ctx context.Context, opts Options, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Auth != "" {
+		req.Header.Set("Authorization", opts.Auth)
+	}
+	return req, nil
+}
+
+// headFile issues a HEAD against the LFS-resolving download URL to learn
+// the file's ETag and size without downloading it.
+func headFile(ctx context.Context, client *http.Client, opts Options, repo, file string) (etag string, size int64, err error) {
+	req, err := newRequest(ctx, opts, http.MethodHead, resolveURL(opts, repo, file))
+	if err != nil {
+		return "", 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("HEAD %s returned %s", file, resp.Status)
+	}
+
+	size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	return strings.Trim(resp.Header.Get("ETag"), `"`), size, nil
+}
+
+// downloadFile fetches file into destPath, resuming from a matching
+// .partial file via a Range request.
+func downloadFile(ctx context.Context, client *http.Client, opts Options, repo, file, destPath string, size int64, onProgress func(done, total int64)) (int64, error) {
+	partialPath := destPath + ".partial"
+
+	var offset int64
+	if info, err := os.Stat(partialPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := newRequest(ctx, opts, http.MethodGet, resolveURL(opts, repo, file))
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("downloading %s returned %s", file, resp.Status)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resp.StatusCode == http.StatusPartialContent {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+		offset = 0
+	}
+
+	f, err := os.OpenFile(partialPath, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	done := offset
+	n, err := io.Copy(f, &progressReader{r: resp.Body, onProgress: func(delta int64) {
+		done += delta
+		if onProgress != nil {
+			onProgress(done, size)
+		}
+	}})
+	if err != nil {
+		return n, err
+	}
+
+	if err := os.Rename(partialPath, destPath); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+type progressReader struct {
+	r          io.Reader
+	onProgress func(int64)
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 && p.onProgress != nil {
+		p.onProgress(int64(n))
+	}
+	return n, err
+}
+
+func readManifest(path string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+func writeManifest(path string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
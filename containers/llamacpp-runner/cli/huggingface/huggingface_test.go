@@ -0,0 +1,239 @@
+package huggingface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		wantRepo  string
+		wantFile  string
+		wantQuant string
+		wantOK    bool
+	}{
+		{
+			name:     "huggingface scheme",
+			ref:      "huggingface://TheBloke/Llama-2-7B-GGUF/llama-2-7b.Q4_K_M.gguf",
+			wantRepo: "TheBloke/Llama-2-7B-GGUF",
+			wantFile: "llama-2-7b.Q4_K_M.gguf",
+			wantOK:   true,
+		},
+		{
+			name:      "hf shorthand with quant",
+			ref:       "hf:TheBloke/Llama-2-7B-GGUF:Q4_K_M",
+			wantRepo:  "TheBloke/Llama-2-7B-GGUF",
+			wantQuant: "Q4_K_M",
+			wantOK:    true,
+		},
+		{
+			name:     "hf shorthand without quant",
+			ref:      "hf:TheBloke/Llama-2-7B-GGUF",
+			wantRepo: "TheBloke/Llama-2-7B-GGUF",
+			wantOK:   true,
+		},
+		{
+			name:   "hf shorthand missing repo segment",
+			ref:    "hf:TheBloke",
+			wantOK: false,
+		},
+		{
+			name:   "huggingface scheme missing file",
+			ref:    "huggingface://TheBloke/Llama-2-7B-GGUF",
+			wantOK: false,
+		},
+		{
+			name:   "ollama ref is not a huggingface ref",
+			ref:    "gemma3:1b",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, file, quant, ok := ParseRef(tt.ref)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRef(%q) ok = %v, want %v", tt.ref, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if repo != tt.wantRepo || file != tt.wantFile || quant != tt.wantQuant {
+				t.Errorf("ParseRef(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.ref, repo, file, quant, tt.wantRepo, tt.wantFile, tt.wantQuant)
+			}
+		})
+	}
+}
+
+// hubServer serves HEAD/GET for a single file at /<repo>/resolve/main/<file>,
+// the same path layout resolveURL builds, supporting Range requests the way
+// the real Hub's LFS redirect does.
+func hubServer(content, etag string, hits *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil && r.Method == http.MethodGet {
+			*hits++
+		}
+		w.Header().Set("ETag", `"`+etag+`"`)
+
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		body := content
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, err := parseRangeStart(rng)
+			if err != nil {
+				status = http.StatusBadRequest
+			} else {
+				body = content[start:]
+				status = http.StatusPartialContent
+			}
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func parseRangeStart(header string) (int, error) {
+	header = strings.TrimSuffix(strings.TrimPrefix(header, "bytes="), "-")
+	return strconv.Atoi(header)
+}
+
+func TestPullDownloadsThenSkipsOnSecondCall(t *testing.T) {
+	content := strings.Repeat("x", 1000)
+	var gets int
+	server := hubServer(content, "etag-1", &gets)
+	defer server.Close()
+
+	dir := t.TempDir()
+	opts := Options{Endpoint: server.URL, ModelDir: dir}
+	ref := "huggingface://org/repo/model.gguf"
+
+	n, err := Pull(context.Background(), ref, opts, nil)
+	if err != nil {
+		t.Fatalf("first Pull: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Fatalf("first Pull downloaded %d bytes, want %d", n, len(content))
+	}
+	if gets != 1 {
+		t.Fatalf("expected 1 GET against the Hub, got %d", gets)
+	}
+
+	destPath := filepath.Join(dir, "org", "repo", "model.gguf")
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatalf("reading downloaded file: %v", err)
+	}
+	if string(got) != content {
+		t.Fatalf("downloaded file content mismatch")
+	}
+
+	n, err = Pull(context.Background(), ref, opts, nil)
+	if err != nil {
+		t.Fatalf("second Pull: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("second Pull downloaded %d bytes, want 0 (manifest should short-circuit)", n)
+	}
+	if gets != 1 {
+		t.Errorf("second Pull issued a GET against the Hub, want the manifest to skip it entirely (gets=%d)", gets)
+	}
+}
+
+// TestPullRedownloadsWhenFileMissingDespiteManifest guards against the bug
+// fixed for a deleted blob whose .manifest.json sidecar survived: Pull must
+// not trust a matching ETag alone when destPath itself is gone, or it
+// silently reports a complete download with nothing on disk.
+func TestPullRedownloadsWhenFileMissingDespiteManifest(t *testing.T) {
+	content := strings.Repeat("y", 500)
+	var gets int
+	server := hubServer(content, "etag-1", &gets)
+	defer server.Close()
+
+	dir := t.TempDir()
+	opts := Options{Endpoint: server.URL, ModelDir: dir}
+	ref := "huggingface://org/repo/model.gguf"
+
+	if _, err := Pull(context.Background(), ref, opts, nil); err != nil {
+		t.Fatalf("seeding Pull: %v", err)
+	}
+	if gets != 1 {
+		t.Fatalf("seeding Pull issued %d GETs, want 1", gets)
+	}
+
+	destPath := filepath.Join(dir, "org", "repo", "model.gguf")
+	if err := os.Remove(destPath); err != nil {
+		t.Fatalf("removing downloaded file to simulate external cleanup: %v", err)
+	}
+
+	n, err := Pull(context.Background(), ref, opts, nil)
+	if err != nil {
+		t.Fatalf("Pull after file was removed: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("Pull after file was removed downloaded %d bytes, want %d (should not trust a stale manifest)", n, len(content))
+	}
+	if gets != 2 {
+		t.Errorf("Pull after file was removed issued %d GETs, want 2 (it must not have skipped)", gets)
+	}
+	if _, err := os.Stat(destPath); err != nil {
+		t.Errorf("expected %s to exist after re-download: %v", destPath, err)
+	}
+}
+
+func TestPullResumesFromPartialFile(t *testing.T) {
+	content := strings.Repeat("z", 2000)
+	server := hubServer(content, "etag-1", nil)
+	defer server.Close()
+
+	dir := t.TempDir()
+	opts := Options{Endpoint: server.URL, ModelDir: dir}
+	ref := "huggingface://org/repo/model.gguf"
+
+	destDir := filepath.Join(dir, "org", "repo")
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		t.Fatalf("seeding dest dir: %v", err)
+	}
+	const preexisting = 1200
+	partialPath := filepath.Join(destDir, "model.gguf.partial")
+	if err := os.WriteFile(partialPath, []byte(content[:preexisting]), 0o644); err != nil {
+		t.Fatalf("seeding .partial file: %v", err)
+	}
+
+	var progressed int64
+	n, err := Pull(context.Background(), ref, opts, func(done, total int64) {
+		progressed = done
+	})
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if n != int64(len(content)-preexisting) {
+		t.Errorf("Pull downloaded %d bytes this call, want %d (only the remainder)", n, len(content)-preexisting)
+	}
+	if progressed != int64(len(content)) {
+		t.Errorf("final progress = %d, want %d (full file size)", progressed, len(content))
+	}
+
+	destPath := filepath.Join(destDir, "model.gguf")
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatalf("reading resumed file: %v", err)
+	}
+	if string(got) != content {
+		t.Fatalf("resumed file content mismatch")
+	}
+}
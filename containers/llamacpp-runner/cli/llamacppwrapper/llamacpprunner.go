@@ -1,113 +1,404 @@
+// Package llamacppwrapper pulls models straight from an Ollama-compatible
+// registry: it resolves the OCI manifest for a ref, then downloads each
+// blob with resumable HTTP range requests, verifying its digest before
+// making it available.
 package llamacppwrapper
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
 	"os"
-	"os/exec"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 )
 
-func generateProgressBar(currentStep int, totalSteps int, barWidth int) string {
-	if totalSteps <= 0 {
-		return "[]"
-	}
-	if currentStep < 0 {
-		currentStep = 0
+// Options configures where and how a model is pulled from the registry.
+type Options struct {
+	// Registry is the host serving the v2 API, e.g. "registry.ollama.ai".
+	Registry string
+	// Insecure allows talking to Registry over plain HTTP.
+	Insecure bool
+	// Auth, if set, is sent as the Authorization header on every request.
+	Auth string
+	// BlobDir is where downloaded blobs (and their .partial files) live.
+	BlobDir string
+}
+
+// DefaultOptions returns the options used when the CLI is invoked without
+// --registry/--insecure/--auth overrides.
+func DefaultOptions() Options {
+	return Options{
+		Registry: "registry.ollama.ai",
+		BlobDir:  "models",
 	}
-	if currentStep > totalSteps {
-		currentStep = totalSteps
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a context carrying opts for DownloadMode to pick up.
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// OptionsFromContext returns the Options carried by ctx, or DefaultOptions()
+// if none were attached.
+func OptionsFromContext(ctx context.Context) Options {
+	if opts, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return opts
 	}
+	return DefaultOptions()
+}
 
-	progress := float64(currentStep) / float64(totalSteps)
-	percent := int(progress * 100)
-	filledChars := int(float64(barWidth) * progress)
-	emptyChars := barWidth - filledChars
+// DownloadResult captures the outcome of pulling a single model so callers
+// driving several pulls concurrently can summarize failures without losing
+// per-model status.
+type DownloadResult struct {
+	Model    string
+	Bytes    int64
+	Duration time.Duration
+	ExitCode int
+	Err      error
+}
 
-	bar := "[" + strings.Repeat("#", filledChars) + strings.Repeat("-", emptyChars) + "]"
+// manifest mirrors the subset of the OCI image manifest the Ollama registry
+// serves at /v2/<namespace>/<name>/manifests/<tag>.
+type manifest struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	MediaType     string  `json:"mediaType"`
+	Config        layer   `json:"config"`
+	Layers        []layer `json:"layers"`
+}
 
-	return fmt.Sprintf("\rProcessing: %s %3d%% Complete (%d/%d)", bar, percent, currentStep, totalSteps)
+type layer struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
 }
 
-func DownloadMode(model string) (int, error) {
-	if inf, err := os.Stat(fmt.Sprintf("%s.partial", model)); err == nil && inf.Size() > 0 {
-		fmt.Println("Resuming partial download...")
-	}
+const maxRetries = 5
+
+// responseHeaderTimeout bounds how long we wait for the registry to start
+// responding to a request. It must not bound the body read too: blobs are
+// multi-hundred-MB to multi-GB gguf files, and a single deadline covering
+// the whole request (as http.Client.Timeout does) would abort any real
+// download partway through. Overall cancellation is the caller's ctx.
+const responseHeaderTimeout = 30 * time.Second
+
+// newHTTPClient returns a client whose Timeout is unbounded so that slow
+// blob transfers aren't killed mid-stream; only the time to first response
+// byte is bounded, via the transport's ResponseHeaderTimeout.
+func newHTTPClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: responseHeaderTimeout}}
+}
+
+// DownloadMode pulls model, reporting aggregate byte progress via
+// onProgress(bytesDone, bytesTotal) as it goes. The registry, auth, and
+// storage location are read from ctx (see WithOptions); without one
+// attached, DefaultOptions() is used.
+func DownloadMode(ctx context.Context, model string, onProgress func(done, total int64)) DownloadResult {
+	start := time.Now()
+	result := DownloadResult{Model: model}
 
-	cmd := exec.Command("llama-run", model, "-ngl", "16", "\"1+1=?\"")
+	opts := OptionsFromContext(ctx)
+	namespace, name, tag := parseRef(model)
+	client := newHTTPClient()
 
-	stdoutPipe, err := cmd.StdoutPipe()
+	m, err := resolveManifest(ctx, client, opts, namespace, name, tag)
 	if err != nil {
-		panic(err)
-	}
-	cmd.Stderr = cmd.Stdout
-
-	if err := cmd.Start(); err != nil {
-		panic(err)
-	}
-
-	go func() {
-		whitespaceRe := regexp.MustCompile(`\s+\|`)
-		fullCharRe := regexp.MustCompile(`[^\x00-\x7F]+`)
-		replaceTag := []byte("")
-
-		buf := make([]byte, 2048)
-		for {
-			n, err := stdoutPipe.Read(buf)
-			if n > 0 {
-				out := whitespaceRe.ReplaceAll(buf[:n], replaceTag)
-				out = fullCharRe.ReplaceAll(out, replaceTag)
-				line := strings.TrimSpace(string(out))
-				if line == "" {
-					continue
-				} else if strings.Contains(line, "%") {
-					// Extract and print progress
-					parts := strings.Split(line, "%")
-					if len(parts) > 0 {
-						progress := strings.TrimSpace(parts[0])
-						if strings.Contains(progress, " ") {
-							splitted := strings.Split(progress, " ")
-							if len(splitted) > 1 {
-								percent, err := strconv.Atoi(splitted[1])
-								if err == nil {
-									barOut := generateProgressBar(percent, 100, 30)
-									fmt.Print(barOut)
-								} else {
-									fmt.Printf("\r%s%%", progress)
-								}
-							}
-						}
-					}
-				}
-			}
-			if err != nil {
-				break
+		result.Err = fmt.Errorf("resolving manifest for %s: %w", model, err)
+		result.ExitCode = 1
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	blobs := append([]layer{m.Config}, m.Layers...)
+	var total int64
+	for _, b := range blobs {
+		total += b.Size
+	}
+
+	var done int64
+	for _, b := range blobs {
+		n, err := downloadBlob(ctx, client, opts, namespace, name, b, func(delta int64) {
+			done += delta
+			if onProgress != nil {
+				onProgress(done, total)
 			}
+		})
+		result.Bytes += n
+		if err != nil {
+			result.Err = fmt.Errorf("downloading blob %s for %s: %w", b.Digest, model, err)
+			result.ExitCode = 1
+			result.Duration = time.Since(start)
+			return result
 		}
-	}()
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
 
-	// Wait for command to finish
-	err = cmd.Wait()
+// VerifyLocal checks that model's blobs already on disk under opts.BlobDir
+// match the digests in the registry manifest, without downloading anything.
+func VerifyLocal(ctx context.Context, model string, opts Options) error {
+	namespace, name, tag := parseRef(model)
+	client := newHTTPClient()
 
-	// Get exit code
-	exitCode := 0
+	m, err := resolveManifest(ctx, client, opts, namespace, name, tag)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-				exitCode = status.ExitStatus()
-			}
-		} else {
-			fmt.Println("Error running command:", err)
+		return fmt.Errorf("resolving manifest for %s: %w", model, err)
+	}
+
+	blobs := append([]layer{m.Config}, m.Layers...)
+	for _, b := range blobs {
+		digestHex := strings.TrimPrefix(b.Digest, "sha256:")
+		path := filepath.Join(opts.BlobDir, "sha256-"+digestHex)
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("blob %s not downloaded: %w", b.Digest, err)
+		}
+		if err := verifyDigest(path, digestHex); err != nil {
+			return fmt.Errorf("blob %s: %w", b.Digest, err)
 		}
+	}
+	return nil
+}
+
+// parseRef splits a model ref like "gemma3:1b" or "myorg/gemma3:1b" into its
+// namespace (defaulting to "library"), name, and tag (defaulting to
+// "latest").
+func parseRef(ref string) (namespace, name, tag string) {
+	namespace = "library"
+	tag = "latest"
+
+	if at := strings.LastIndex(ref, ":"); at != -1 {
+		tag = ref[at+1:]
+		ref = ref[:at]
+	}
+	if slash := strings.Index(ref, "/"); slash != -1 {
+		namespace = ref[:slash]
+		name = ref[slash+1:]
 	} else {
-		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
-			exitCode = status.ExitStatus()
+		name = ref
+	}
+	return namespace, name, tag
+}
+
+func (o Options) baseURL() string {
+	scheme := "https"
+	if o.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s", scheme, o.Registry)
+}
+
+func (o Options) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if o.Auth != "" {
+		req.Header.Set("Authorization", o.Auth)
+	}
+	return req, nil
+}
+
+func resolveManifest(ctx context.Context, client *http.Client, opts Options, namespace, name, tag string) (*manifest, error) {
+	url := fmt.Sprintf("%s/v2/%s/%s/manifests/%s", opts.baseURL(), namespace, name, tag)
+	req, err := opts.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{op: "manifest request", statusCode: resp.StatusCode, status: resp.Status}
+	}
+
+	var m manifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// downloadBlob fetches b into opts.BlobDir, resuming from a matching
+// .partial file via a Range request and retrying transient failures with
+// exponential backoff. It verifies the digest before renaming the file into
+// place and returns the number of bytes it downloaded this call.
+func downloadBlob(ctx context.Context, client *http.Client, opts Options, namespace, name string, b layer, onProgress func(int64)) (int64, error) {
+	digestHex := strings.TrimPrefix(b.Digest, "sha256:")
+	finalPath := filepath.Join(opts.BlobDir, "sha256-"+digestHex)
+	partialPath := finalPath + ".partial"
+
+	if info, err := os.Stat(finalPath); err == nil && info.Size() == b.Size {
+		onProgress(b.Size)
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(opts.BlobDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	// A .partial file from an earlier run already has bytes on disk that
+	// attemptDownload will never read (and so never report): account for
+	// them once up front so a resumed blob's progress reaches 100%.
+	if info, err := os.Stat(partialPath); err == nil && info.Size() > 0 {
+		onProgress(info.Size())
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/%s/blobs/%s", opts.baseURL(), namespace, name, b.Digest)
+
+	var downloaded int64
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<attempt)*100*time.Millisecond + time.Duration(rand.Intn(100))*time.Millisecond
+			time.Sleep(backoff)
 		}
+
+		n, err := attemptDownload(ctx, client, opts, url, partialPath, onProgress)
+		downloaded += n
+		if err == nil {
+			break
+		}
+		lastErr = err
+		if !isRetryable(err) {
+			return downloaded, err
+		}
+	}
+	if lastErr != nil {
+		if info, statErr := os.Stat(partialPath); statErr != nil || info.Size() < b.Size {
+			return downloaded, fmt.Errorf("after %d attempts: %w", maxRetries, lastErr)
+		}
+	}
+
+	if err := verifyDigest(partialPath, digestHex); err != nil {
+		return downloaded, err
+	}
+	if err := os.Rename(partialPath, finalPath); err != nil {
+		return downloaded, err
+	}
+	return downloaded, nil
+}
+
+// attemptDownload issues a single (possibly resumed) request for url and
+// streams the response into partialPath, reporting each chunk via
+// onProgress.
+func attemptDownload(ctx context.Context, client *http.Client, opts Options, url, partialPath string, onProgress func(int64)) (int64, error) {
+	var offset int64
+	if info, err := os.Stat(partialPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := opts.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	fmt.Println("Process finished with exit code:", exitCode)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
 
-	return exitCode, nil
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, &httpStatusError{op: "blob request", statusCode: resp.StatusCode, status: resp.Status}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if resp.StatusCode == http.StatusPartialContent {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+		offset = 0
+	}
+
+	f, err := os.OpenFile(partialPath, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	written, err := io.Copy(f, &progressReader{r: resp.Body, onProgress: onProgress})
+	if err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// progressReader reports every chunk it reads from r via onProgress.
+type progressReader struct {
+	r          io.Reader
+	onProgress func(int64)
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 && p.onProgress != nil {
+		p.onProgress(int64(n))
+	}
+	return n, err
+}
+
+// httpStatusError wraps a non-2xx registry response so callers can tell a
+// transient server error from a permanent client error by status code
+// instead of matching on the formatted message.
+type httpStatusError struct {
+	op         string
+	statusCode int
+	status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s returned %s", e.op, e.status)
+}
+
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= 500
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "EOF") || strings.Contains(msg, "connection reset")
+}
+
+func verifyDigest(path, wantHex string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	gotHex := hex.EncodeToString(h.Sum(nil))
+	if gotHex != wantHex {
+		return fmt.Errorf("digest mismatch: want sha256:%s, got sha256:%s", wantHex, gotHex)
+	}
+	return nil
 }
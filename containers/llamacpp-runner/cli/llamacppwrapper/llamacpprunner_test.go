@@ -0,0 +1,82 @@
+package llamacppwrapper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestDownloadBlobReportsPreexistingBytesOnResume guards against a resumed
+// blob's progress never reaching 100%: the bytes a .partial file already
+// has on disk before this call must be reported too, not just the bytes
+// read off the (ranged) response body.
+func TestDownloadBlobReportsPreexistingBytesOnResume(t *testing.T) {
+	content := strings.Repeat("x", 1000)
+	sum := sha256.Sum256([]byte(content))
+	digestHex := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := content
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, err := parseRangeStart(rng)
+			if err != nil {
+				t.Fatalf("parsing Range header %q: %v", rng, err)
+			}
+			body = content[start:]
+			status = http.StatusPartialContent
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	blobDir := t.TempDir()
+	partialPath := filepath.Join(blobDir, "sha256-"+digestHex+".partial")
+	const preexisting = 600
+	if err := os.WriteFile(partialPath, []byte(content[:preexisting]), 0o644); err != nil {
+		t.Fatalf("seeding .partial file: %v", err)
+	}
+
+	host := mustHost(t, server.URL)
+	opts := Options{Registry: host, Insecure: true, BlobDir: blobDir}
+
+	var done int64
+	_, err := downloadBlob(context.Background(), server.Client(), opts, "testns", "testname", layer{
+		Digest: "sha256:" + digestHex,
+		Size:   int64(len(content)),
+	}, func(delta int64) {
+		done += delta
+	})
+	if err != nil {
+		t.Fatalf("downloadBlob: %v", err)
+	}
+
+	if done != int64(len(content)) {
+		t.Errorf("reported done=%d, want %d (full blob size)", done, len(content))
+	}
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("parsing server URL %q: %v", rawURL, err)
+	}
+	return u.Host
+}
+
+// parseRangeStart extracts the start offset from a "bytes=<start>-" header,
+// which is all attemptDownload ever sends.
+func parseRangeStart(header string) (int, error) {
+	header = strings.TrimSuffix(strings.TrimPrefix(header, "bytes="), "-")
+	return strconv.Atoi(header)
+}
@@ -0,0 +1,134 @@
+// Package modeldl is the I/O-driving, but otherwise UI-free, API for
+// pulling models. cmd/ wraps a Client with a thin cobra adapter that
+// subscribes to its progress channel and renders it; other Go programs can
+// embed Client directly (see examples/embed).
+package modeldl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arduino/app-bricks-py/model-downloader/backend"
+	"github.com/arduino/app-bricks-py/model-downloader/llamacppwrapper"
+)
+
+// defaultBackend is used when a ref carries no recognizable scheme (plain
+// Ollama refs like "gemma3:1b").
+const defaultBackend = "llama-run"
+
+// Event reports the progress of an in-flight pull.
+type Event struct {
+	Model      string
+	Stage      string
+	Percent    int
+	BytesDone  int64
+	BytesTotal int64
+	// Err is the human-readable reason the pull failed. Set only when
+	// Stage is "error".
+	Err string
+}
+
+// PullOptions customizes how Pull resolves and drives a backend.
+type PullOptions struct {
+	// Backend names the backend to use when ref carries no recognizable
+	// scheme (e.g. "llama-run", "plugin:vllm"). Defaults to "llama-run".
+	Backend string
+	// Registry, Insecure, and Auth configure the Ollama registry backend.
+	Registry string
+	Insecure bool
+	Auth     string
+}
+
+// Client pulls models through the registered backend.Puller implementations.
+// The zero value is ready to use.
+type Client struct{}
+
+// New returns a ready-to-use Client.
+func New() *Client { return &Client{} }
+
+// Pull starts downloading ref and returns a handle for observing its
+// progress and outcome. The download runs in the background; cancel ctx (or
+// call PullHandle.Cancel) to stop it early.
+func (c *Client) Pull(ctx context.Context, ref string, opts PullOptions) (*PullHandle, error) {
+	backendName := opts.Backend
+	if backendName == "" {
+		backendName = defaultBackend
+	}
+
+	puller, err := backend.ForRef(ref, backendName)
+	if err != nil {
+		return nil, err
+	}
+
+	pullCtx, cancel := context.WithCancel(ctx)
+	pullCtx = llamacppwrapper.WithOptions(pullCtx, registryOptions(opts))
+
+	backendProgress, err := puller.Pull(pullCtx, ref)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	h := &PullHandle{
+		progress: make(chan Event, 16),
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+
+	go func() {
+		defer cancel()
+		defer close(h.progress)
+		defer close(h.done)
+		for p := range backendProgress {
+			h.progress <- Event{Model: ref, Stage: p.Stage, Percent: p.Percent, BytesDone: p.BytesDone, BytesTotal: p.BytesTotal, Err: p.Err}
+			if p.Stage == "error" {
+				msg := p.Err
+				if msg == "" {
+					msg = fmt.Sprintf("backend %s reported an error pulling %s with no further detail", puller.Name(), ref)
+				}
+				h.err = fmt.Errorf("pulling %s: %s", ref, msg)
+			}
+		}
+	}()
+
+	return h, nil
+}
+
+// Verify checks that ref's already-downloaded blobs match their expected
+// digests, without re-downloading anything.
+func (c *Client) Verify(ctx context.Context, ref string, opts PullOptions) error {
+	return llamacppwrapper.VerifyLocal(ctx, ref, registryOptions(opts))
+}
+
+func registryOptions(opts PullOptions) llamacppwrapper.Options {
+	registryOpts := llamacppwrapper.DefaultOptions()
+	if opts.Registry != "" {
+		registryOpts.Registry = opts.Registry
+	}
+	registryOpts.Insecure = opts.Insecure
+	registryOpts.Auth = opts.Auth
+	return registryOpts
+}
+
+// PullHandle observes and controls one in-flight Pull.
+type PullHandle struct {
+	progress chan Event
+	done     chan struct{}
+	err      error
+	cancel   context.CancelFunc
+}
+
+// Progress streams events until the pull finishes; the channel is closed
+// once Wait would return.
+func (h *PullHandle) Progress() <-chan Event { return h.progress }
+
+// Wait blocks until the pull finishes and returns its terminal error, if
+// any.
+func (h *PullHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Cancel stops the pull early; Wait will then return the backend's
+// cancellation error.
+func (h *PullHandle) Cancel() { h.cancel() }